@@ -18,9 +18,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/prometheus/prometheus/pkg/rulefmt"
 	"github.com/vatine/promgraph/pkg/rulegraph"
 )
 
@@ -63,21 +65,71 @@ func output(designator string) io.Writer {
 
 func main() {
 	out := flag.String("output", "-", "Output file (use '-' for stdout).")
+	format := flag.String("format", "dot", "Output format: dot, mermaid, graphml or json.")
+	checkCycles := flag.Bool("check-cycles", false, "Exit non-zero if any recording rule (transitively) depends on itself.")
+	report := flag.Bool("report", false, "Print unused recording rules and alerts referencing nothing known, and exit non-zero if any are found.")
+	prometheusURL := flag.String("prometheus-url", "", "Fetch rules from a live Prometheus's /api/v1/rules instead of reading files.")
+	bearerToken := flag.String("bearer-token", "", "Bearer token to use when --prometheus-url is set.")
 	flag.Parse()
 
-	filenames := files(flag.Args())
 	sink := output(*out)
-
 	if closer, ok := sink.(io.WriteCloser); ok {
 		defer closer.Close()
 	}
 
-	rules, err := rulegraph.LoadRuleFiles(filenames...)
+	var rules []rulefmt.RuleGroup
+	var err error
+
+	if *prometheusURL != "" {
+		var opts []rulegraph.Option
+		if *bearerToken != "" {
+			opts = append(opts, rulegraph.WithBearerToken(*bearerToken))
+		}
+		rules, err = rulegraph.LoadRulesFromAPI(*prometheusURL, opts...)
+	} else {
+		filenames := files(flag.Args())
+		rules, err = rulegraph.LoadRuleFiles(filenames...)
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Fatal("Failed to parse rules, aborting.")
 	}
 	graph := rulegraph.BuildRuleDiagram(rules)
-	rulegraph.EmitGraph(graph, sink)
+
+	if *checkCycles {
+		cycles := graph.Cycles()
+		if len(cycles) > 0 {
+			for _, cycle := range cycles {
+				log.WithFields(log.Fields{
+					"cycle": strings.Join(cycle, " -> "),
+				}).Error("Found a dependency cycle.")
+			}
+			os.Exit(1)
+		}
+	}
+
+	if *report {
+		recordedUnused, alertsWithoutRoute := graph.Orphans()
+		for _, name := range recordedUnused {
+			log.WithFields(log.Fields{
+				"rule": name,
+			}).Warn("Recording rule has no alert depending on it.")
+		}
+		for _, name := range alertsWithoutRoute {
+			log.WithFields(log.Fields{
+				"alert": name,
+			}).Warn("Alert references only unknown metrics.")
+		}
+		if len(recordedUnused)+len(alertsWithoutRoute) > 0 {
+			os.Exit(1)
+		}
+	}
+
+	if err := rulegraph.Emit(*format, graph, sink); err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"format": *format,
+		}).Fatal("Failed to emit graph, aborting.")
+	}
 }
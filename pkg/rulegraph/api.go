@@ -0,0 +1,207 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+// apiOptions holds everything LoadRulesFromAPI needs beyond the base
+// URL, all set through the functional Option constructors below.
+type apiOptions struct {
+	bearerToken string
+	basicUser   string
+	basicPass   string
+	tlsConfig   *tls.Config
+	client      *http.Client
+}
+
+// Option configures a call to LoadRulesFromAPI.
+type Option func(*apiOptions)
+
+// WithBearerToken authenticates the request with an HTTP bearer token.
+func WithBearerToken(token string) Option {
+	return func(o *apiOptions) {
+		o.bearerToken = token
+	}
+}
+
+// WithBasicAuth authenticates the request with HTTP basic auth.
+func WithBasicAuth(user, pass string) Option {
+	return func(o *apiOptions) {
+		o.basicUser = user
+		o.basicPass = pass
+	}
+}
+
+// WithTLSConfig overrides the TLS configuration used to talk to the
+// Prometheus API, e.g. to supply a custom CA or client certificate.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *apiOptions) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithHTTPClient overrides the http.Client used for the request
+// entirely, taking precedence over WithTLSConfig.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *apiOptions) {
+		o.client = client
+	}
+}
+
+// The shapes below mirror the subset of Prometheus's
+// /api/v1/rules response we care about. See
+// https://prometheus.io/docs/prometheus/latest/querying/api/#rules
+// for the full schema.
+type apiRulesResponse struct {
+	Status    string       `json:"status"`
+	Data      apiRulesData `json:"data"`
+	ErrorType string       `json:"errorType"`
+	Error     string       `json:"error"`
+}
+
+type apiRulesData struct {
+	Groups []apiRuleGroup `json:"groups"`
+}
+
+type apiRuleGroup struct {
+	Name        string    `json:"name"`
+	File        string    `json:"file"`
+	Rules       []apiRule `json:"rules"`
+	Interval    float64   `json:"interval"`
+	Limit       int       `json:"limit"`
+	QueryOffset float64   `json:"query_offset"`
+}
+
+type apiRule struct {
+	Type           string            `json:"type"`
+	Name           string            `json:"name"`
+	Query          string            `json:"query"`
+	Duration       float64           `json:"duration"`
+	Labels         map[string]string `json:"labels"`
+	Annotations    map[string]string `json:"annotations"`
+	Health         string            `json:"health"`
+	LastError      string            `json:"lastError"`
+	EvaluationTime float64           `json:"evaluationTime"`
+	LastEvaluation time.Time         `json:"lastEvaluation"`
+}
+
+// toRuleGroups converts the API response into the same
+// []rulefmt.RuleGroup shape LoadRuleFiles produces, so callers can
+// pass it straight to BuildRuleDiagram.
+func (d apiRulesData) toRuleGroups() []rulefmt.RuleGroup {
+	var groups []rulefmt.RuleGroup
+
+	for _, g := range d.Groups {
+		group := rulefmt.RuleGroup{
+			Name:     g.Name,
+			Interval: model.Duration(time.Duration(g.Interval * float64(time.Second))),
+			Limit:    g.Limit,
+		}
+
+		if g.QueryOffset != 0 {
+			qo := model.Duration(time.Duration(g.QueryOffset * float64(time.Second)))
+			group.QueryOffset = &qo
+		}
+
+		for _, r := range g.Rules {
+			node := rulefmt.RuleNode{
+				Expr:        yaml.Node{Value: r.Query},
+				For:         model.Duration(time.Duration(r.Duration * float64(time.Second))),
+				Labels:      r.Labels,
+				Annotations: r.Annotations,
+			}
+
+			switch r.Type {
+			case "recording":
+				node.Record = yaml.Node{Value: r.Name}
+			case "alerting":
+				node.Alert = yaml.Node{Value: r.Name}
+			default:
+				// Unknown rule type; skip it rather than guess
+				// whether it's a recording or alerting rule.
+				continue
+			}
+
+			group.Rules = append(group.Rules, node)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// LoadRulesFromAPI fetches the rule groups currently loaded by a live
+// Prometheus (or Prometheus-API-compatible, e.g. Cortex/Mimir ruler)
+// instance, rather than reading them from files on disk.
+func LoadRulesFromAPI(baseURL string, opts ...Option) ([]rulefmt.RuleGroup, error) {
+	o := &apiOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// WithHTTPClient takes precedence: if the caller supplied their
+	// own client, don't touch its Transport. Otherwise build a
+	// fresh client (never the shared http.DefaultClient singleton)
+	// and apply WithTLSConfig to that.
+	if o.client == nil {
+		o.client = &http.Client{}
+		if o.tlsConfig != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = o.tlsConfig
+			o.client.Transport = transport
+		}
+	}
+
+	url := strings.TrimRight(baseURL, "/") + "/api/v1/rules"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request for %s: %w", url, err)
+	}
+
+	if o.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+o.bearerToken)
+	}
+	if o.basicUser != "" {
+		req.SetBasicAuth(o.basicUser, o.basicPass)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	var payload apiRulesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decoding response from %s: %w", url, err)
+	}
+
+	if payload.Status != "success" {
+		return nil, fmt.Errorf("prometheus API at %s returned status %q: %s", url, payload.Status, payload.Error)
+	}
+
+	return payload.Data.toRuleGroups(), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestToRuleGroups(t *testing.T) {
+	data := apiRulesData{
+		Groups: []apiRuleGroup{
+			{
+				Name:        "test",
+				Interval:    30,
+				QueryOffset: 15,
+				Rules: []apiRule{
+					{Type: "recording", Name: "test:rule:sum", Query: "sum(up)"},
+					{Type: "alerting", Name: "TestAlert", Query: "test:rule:sum > 0", Duration: 60},
+					{Type: "unknown", Name: "Mystery", Query: "1"},
+				},
+			},
+		},
+	}
+
+	groups := data.toRuleGroups()
+	if len(groups) != 1 {
+		t.Fatalf("saw %d groups, want 1", len(groups))
+	}
+
+	group := groups[0]
+	if group.Interval != model.Duration(30*time.Second) {
+		t.Errorf("interval: saw %v, want 30s", group.Interval)
+	}
+	if group.QueryOffset == nil || *group.QueryOffset != model.Duration(15*time.Second) {
+		t.Errorf("query_offset: saw %v, want 15s", group.QueryOffset)
+	}
+
+	// The unknown-typed rule should have been dropped.
+	if len(group.Rules) != 2 {
+		t.Fatalf("saw %d rules, want 2", len(group.Rules))
+	}
+
+	record := group.Rules[0]
+	if record.Record.Value != "test:rule:sum" {
+		t.Errorf("record name: saw %q, want %q", record.Record.Value, "test:rule:sum")
+	}
+
+	alertRule := group.Rules[1]
+	if alertRule.Alert.Value != "TestAlert" {
+		t.Errorf("alert name: saw %q, want %q", alertRule.Alert.Value, "TestAlert")
+	}
+	if alertRule.For != model.Duration(60*time.Second) {
+		t.Errorf("for: saw %v, want 60s", alertRule.For)
+	}
+}
+
+func TestToRuleGroupsNoQueryOffset(t *testing.T) {
+	data := apiRulesData{
+		Groups: []apiRuleGroup{
+			{Name: "test", Interval: 30},
+		},
+	}
+
+	groups := data.toRuleGroups()
+	if groups[0].QueryOffset != nil {
+		t.Errorf("query_offset: saw %v, want nil", groups[0].QueryOffset)
+	}
+}
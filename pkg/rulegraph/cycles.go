@@ -0,0 +1,106 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+// tarjanState holds the per-node bookkeeping Tarjan's strongly
+// connected component algorithm needs, plus the shared stack and
+// counters used while walking the graph.
+type tarjanState struct {
+	index   map[string]int
+	lowlink map[string]int
+	onStack map[string]bool
+	stack   []string
+	next    int
+	sccs    [][]string
+}
+
+func (g *Graph) tarjan(v string, st *tarjanState) {
+	st.index[v] = st.next
+	st.lowlink[v] = st.next
+	st.next++
+	st.stack = append(st.stack, v)
+	st.onStack[v] = true
+
+	for _, w := range g.adj[v] {
+		if _, seen := st.index[w]; !seen {
+			g.tarjan(w, st)
+			if st.lowlink[w] < st.lowlink[v] {
+				st.lowlink[v] = st.lowlink[w]
+			}
+		} else if st.onStack[w] {
+			if st.index[w] < st.lowlink[v] {
+				st.lowlink[v] = st.index[w]
+			}
+		}
+	}
+
+	if st.lowlink[v] == st.index[v] {
+		var scc []string
+		for {
+			n := len(st.stack) - 1
+			w := st.stack[n]
+			st.stack = st.stack[:n]
+			st.onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		st.sccs = append(st.sccs, scc)
+	}
+}
+
+// hasSelfEdge reports whether node name has an edge back to itself,
+// i.e. a rule that (directly) depends on its own output.
+func (g *Graph) hasSelfEdge(name string) bool {
+	for _, next := range g.adj[name] {
+		if next == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Cycles returns every nontrivial strongly connected component of the
+// graph: groups of two or more nodes that depend on each other
+// (directly or transitively), plus any single node that depends on
+// itself. A recording rule chain with one of these is a footgun -
+// whichever rule evaluates last in the cycle will read data that is
+// at best one evaluation interval stale, and the cycle will never
+// resolve.
+//
+// Computed with Tarjan's SCC algorithm over g.adj.
+func (g *Graph) Cycles() [][]string {
+	st := &tarjanState{
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+
+	for name := range g.nodes {
+		if _, seen := st.index[name]; !seen {
+			g.tarjan(name, st)
+		}
+	}
+
+	var cycles [][]string
+	for _, scc := range st.sccs {
+		if len(scc) > 1 || (len(scc) == 1 && g.hasSelfEdge(scc[0])) {
+			cycles = append(cycles, scc)
+		}
+	}
+
+	return cycles
+}
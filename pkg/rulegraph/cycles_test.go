@@ -0,0 +1,82 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sccKey(scc []string) string {
+	cp := append([]string{}, scc...)
+	sort.Strings(cp)
+	return strings.Join(cp, ",")
+}
+
+func TestCycles(t *testing.T) {
+	cases := []struct {
+		name  string
+		nodes []string
+		adj   map[string][]string
+		want  []string
+	}{
+		{
+			"no cycle",
+			[]string{"a", "b", "c"},
+			map[string][]string{"a": {"b"}, "b": {"c"}},
+			nil,
+		},
+		{
+			"self edge",
+			[]string{"a", "b"},
+			map[string][]string{"a": {"a"}, "b": {}},
+			[]string{"a"},
+		},
+		{
+			"two node cycle",
+			[]string{"a", "b", "c"},
+			map[string][]string{"a": {"b"}, "b": {"a", "c"}},
+			[]string{"a,b"},
+		},
+	}
+
+	for _, c := range cases {
+		g := newGraph()
+		for _, n := range c.nodes {
+			g.nodes[n] = nodeInfo{kind: recorded}
+		}
+		for from, tos := range c.adj {
+			g.adj[from] = tos
+		}
+
+		var got []string
+		for _, scc := range g.Cycles() {
+			got = append(got, sccKey(scc))
+		}
+		sort.Strings(got)
+		sort.Strings(c.want)
+
+		if len(got) != len(c.want) {
+			t.Errorf("Case %q: saw %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for ix := range got {
+			if got[ix] != c.want[ix] {
+				t.Errorf("Case %q: saw %v, want %v", c.name, got, c.want)
+				break
+			}
+		}
+	}
+}
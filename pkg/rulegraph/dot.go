@@ -0,0 +1,169 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// dotEmitter renders a Graph as a Graphviz DOT digraph. It is
+// registered under the name "dot", and is also the default used by
+// cmd/promgraph when --format is not given.
+type dotEmitter struct{}
+
+// durationLabel formats a model.Duration for use in a DOT label,
+// omitting it entirely when it is the zero value so unset fields
+// don't clutter every cluster/node label.
+func durationLabel(prefix string, d model.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s=%s ", prefix, d)
+}
+
+// intLabel is durationLabel's counterpart for int-valued fields (just
+// limit, currently), likewise omitting the zero value.
+func intLabel(prefix string, v int) string {
+	if v == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s=%d ", prefix, v)
+}
+
+// dotName quotes name if it contains any character DOT doesn't allow
+// in a bare identifier - not just ":" (recording rule convention),
+// but also "#" (a comment marker to Graphviz) and "{"/"}" (used by
+// the synthetic "{unmatched}" unknown nodes Visit can produce).
+func dotName(name string) string {
+	if strings.ContainsAny(name, ":#{}") {
+		return fmt.Sprintf("\"%s\"", name)
+	}
+
+	return name
+}
+
+// cycleColors is a small palette of visually distinct Graphviz color
+// names, cycled through by index so each cluster_cycle_N in a graph
+// with several cycles is still told apart from the others.
+var cycleColors = []string{"red", "orange", "purple", "brown", "magenta", "darkgreen"}
+
+func cycleColor(ix int) string {
+	return cycleColors[ix%len(cycleColors)]
+}
+
+func (dotEmitter) Emit(g *Graph, w io.Writer) error {
+	fmt.Fprintf(w, "digraph {\n")
+
+	// Group nodes by the rule group they came from, so each group
+	// can be rendered as its own subgraph cluster. Nodes with no
+	// group (i.e. unknown nodes) are emitted ungrouped.
+	grouped := make(map[string][]string)
+	var groupOrder []string
+	var ungrouped []string
+
+	for name, info := range g.nodes {
+		if info.group == "" {
+			ungrouped = append(ungrouped, name)
+			continue
+		}
+		if _, ok := grouped[info.group]; !ok {
+			groupOrder = append(groupOrder, info.group)
+		}
+		grouped[info.group] = append(grouped[info.group], name)
+	}
+
+	recordedUnused, alertsWithoutRoute := g.Orphans()
+	orphans := make(map[string]bool)
+	for _, name := range recordedUnused {
+		orphans[name] = true
+	}
+	for _, name := range alertsWithoutRoute {
+		orphans[name] = true
+	}
+
+	writeNode := func(name string) {
+		info := g.nodes[name]
+		label := name
+		if info.kind == alert {
+			label += durationLabel("\\nfor", info.for_)
+			label += durationLabel("\\nkeep_firing_for", info.keepFiringFor)
+		}
+		shade := ""
+		if orphans[name] {
+			shade = ", style=filled, fillcolor=lightgrey"
+		}
+		switch info.kind {
+		case recorded:
+			fmt.Fprintf(w, "    %s [shape=oval, label=\"%s\"%s]\n", dotName(name), label, shade)
+		case alert:
+			fmt.Fprintf(w, "    %s [shape=doubleoctagon, label=\"%s\"%s]\n", dotName(name), label, shade)
+		case unknown:
+			fmt.Fprintf(w, "    %s [shape=rect]\n", dotName(name))
+		default:
+			fmt.Fprintf(w, "    /* Unknown node type %v for %s */\n", info.kind, name)
+		}
+	}
+
+	for _, group := range groupOrder {
+		fmt.Fprintf(w, "  subgraph cluster_%s {\n", group)
+		names := grouped[group]
+		clusterLabel := group
+		if len(names) > 0 {
+			first := g.nodes[names[0]]
+			meta := durationLabel("interval", first.groupInterval) + durationLabel("query_offset", first.queryOffset) + intLabel("limit", first.limit)
+			if meta != "" {
+				clusterLabel += "\\n" + strings.TrimSpace(meta)
+			}
+		}
+		fmt.Fprintf(w, "    label=\"%s\"\n", clusterLabel)
+		for _, name := range names {
+			writeNode(name)
+		}
+		fmt.Fprintf(w, "  }\n")
+	}
+
+	for _, name := range ungrouped {
+		writeNode(name)
+	}
+
+	for ix, scc := range g.Cycles() {
+		fmt.Fprintf(w, "  subgraph cluster_cycle_%d {\n", ix)
+		fmt.Fprintf(w, "    color=%s\n", cycleColor(ix))
+		fmt.Fprintf(w, "    label=\"cycle\"\n")
+		for _, name := range scc {
+			fmt.Fprintf(w, "    %s\n", dotName(name))
+		}
+		fmt.Fprintf(w, "  }\n")
+	}
+
+	fmt.Fprintf(w, "\n")
+
+	for edge, info := range g.edges {
+		if info.stale {
+			fmt.Fprintf(w, "  %s [color=red]\n", edge)
+		} else {
+			fmt.Fprintf(w, "  %s\n", edge)
+		}
+	}
+
+	fmt.Fprintf(w, "}\n")
+
+	return nil
+}
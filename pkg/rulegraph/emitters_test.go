@@ -0,0 +1,152 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// sampleEmitGraph builds a small, fixed graph shared by every
+// emitter test: one recording rule feeding one alert.
+func sampleEmitGraph() *Graph {
+	g := newGraph()
+	g.nodes["test:rule:sum"] = nodeInfo{kind: recorded, group: "group1", groupInterval: model.Duration(30 * time.Second), limit: 5}
+	g.nodes["TestAlert"] = nodeInfo{kind: alert, for_: model.Duration(time.Minute), keepFiringFor: model.Duration(5 * time.Minute), annotations: map[string]string{"summary": "it broke"}}
+	g.producers["test:rule:sum"] = []string{"test:rule:sum"}
+	g.adj["TestAlert"] = []string{"test:rule:sum"}
+	g.edges[buildEdge("TestAlert", "test:rule:sum")] = edgeInfo{}
+
+	return g
+}
+
+func TestDotEmitterOutput(t *testing.T) {
+	g := sampleEmitGraph()
+	var buf bytes.Buffer
+
+	if err := (dotEmitter{}).Emit(g, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"digraph {", "test:rule:sum", "TestAlert", "shape=oval", "shape=doubleoctagon", "TestAlert -> \"test:rule:sum\"",
+		"limit=5", "for=1m ", "keep_firing_for=5m ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDotEmitterDistinguishesCycleColors(t *testing.T) {
+	g := newGraph()
+	for _, pair := range [][2]string{{"a", "b"}, {"c", "d"}} {
+		g.nodes[pair[0]] = nodeInfo{kind: recorded}
+		g.nodes[pair[1]] = nodeInfo{kind: recorded}
+		g.adj[pair[0]] = []string{pair[1]}
+		g.adj[pair[1]] = []string{pair[0]}
+	}
+
+	var buf bytes.Buffer
+	if err := (dotEmitter{}).Emit(g, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(g.Cycles()) != 2 {
+		t.Fatalf("test setup: saw %d cycles, want 2", len(g.Cycles()))
+	}
+	if cycleColor(0) == cycleColor(1) {
+		t.Errorf("cycle 0 and cycle 1 got the same color: %q", cycleColor(0))
+	}
+
+	out := buf.String()
+	for _, color := range []string{cycleColor(0), cycleColor(1)} {
+		if !strings.Contains(out, "color="+color) {
+			t.Errorf("output missing cycle color %q:\n%s", color, out)
+		}
+	}
+}
+
+func TestMermaidEmitterOutput(t *testing.T) {
+	g := sampleEmitGraph()
+	var buf bytes.Buffer
+
+	if err := (mermaidEmitter{}).Emit(g, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"flowchart LR", "([test:rule:sum])", "{{TestAlert}}", "TestAlert --> test_rule_sum"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGraphmlEmitterOutput(t *testing.T) {
+	g := sampleEmitGraph()
+	var buf bytes.Buffer
+
+	if err := (graphmlEmitter{}).Emit(g, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc graphmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output isn't valid GraphML: %v\n%s", err, buf.String())
+	}
+
+	if len(doc.Graph.Nodes) != 2 {
+		t.Errorf("saw %d nodes, want 2", len(doc.Graph.Nodes))
+	}
+	if len(doc.Graph.Edges) != 1 {
+		t.Errorf("saw %d edges, want 1", len(doc.Graph.Edges))
+	}
+}
+
+func TestJSONEmitterOutput(t *testing.T) {
+	g := sampleEmitGraph()
+	var buf bytes.Buffer
+
+	if err := (jsonEmitter{}).Emit(g, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc jsonGraph
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if len(doc.Nodes) != 2 || len(doc.Edges) != 1 {
+		t.Fatalf("saw %d nodes, %d edges, want 2 and 1", len(doc.Nodes), len(doc.Edges))
+	}
+
+	// Nodes must come out sorted by name regardless of map
+	// iteration order, so repeated runs over the same input produce
+	// byte-identical output.
+	if doc.Nodes[0].Name != "TestAlert" || doc.Nodes[1].Name != "test:rule:sum" {
+		t.Errorf("nodes not sorted by name: %+v", doc.Nodes)
+	}
+
+	if doc.Nodes[0].Annotations["summary"] != "it broke" {
+		t.Errorf("alert node missing annotations: %+v", doc.Nodes[0])
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// jsonEmitter renders a Graph as a stable, machine-consumable JSON
+// document, registered under the name "json".
+type jsonEmitter struct{}
+
+type jsonNode struct {
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"`
+	Group       string            `json:"group,omitempty"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type jsonEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Stale bool   `json:"stale,omitempty"`
+}
+
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Edges []jsonEdge `json:"edges"`
+}
+
+func (jsonEmitter) Emit(g *Graph, w io.Writer) error {
+	doc := jsonGraph{}
+
+	for name, info := range g.nodes {
+		node := jsonNode{
+			Name:        name,
+			Kind:        ruleTypeName(info.kind),
+			Group:       info.group,
+			Labels:      info.labels,
+			Annotations: info.annotations,
+		}
+		if info.kind == alert && info.for_ != 0 {
+			node.For = info.for_.String()
+		}
+		doc.Nodes = append(doc.Nodes, node)
+	}
+
+	for from, tos := range g.adj {
+		for _, to := range tos {
+			edge := g.edges[buildEdge(from, to)]
+			doc.Edges = append(doc.Edges, jsonEdge{From: from, To: to, Stale: edge.stale})
+		}
+	}
+
+	// g.nodes and g.adj are plain maps, so without this the node and
+	// edge order would vary run to run for the same input.
+	sort.Slice(doc.Nodes, func(i, j int) bool {
+		return doc.Nodes[i].Name < doc.Nodes[j].Name
+	})
+	sort.Slice(doc.Edges, func(i, j int) bool {
+		if doc.Edges[i].From != doc.Edges[j].From {
+			return doc.Edges[i].From < doc.Edges[j].From
+		}
+		return doc.Edges[i].To < doc.Edges[j].To
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
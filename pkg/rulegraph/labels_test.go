@@ -0,0 +1,90 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+func TestLabelAwareEdges(t *testing.T) {
+	g := newGraph()
+	g.nodes["foo"] = nodeInfo{kind: recorded, labels: map[string]string{"job": "api"}}
+	g.producers["foo"] = []string{"foo"}
+	g.nodes["foo#2"] = nodeInfo{kind: recorded, labels: map[string]string{"job": "db"}}
+	g.producers["foo"] = append(g.producers["foo"], "foo#2")
+
+	expr, err := parser.ParseExpr(`foo{job="db"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g.nexts = nil
+	parser.Walk(g, expr, nil)
+
+	if want := "foo#2"; strings.Join(g.nexts, ",") != want {
+		t.Errorf("saw %v, want %v", g.nexts, want)
+	}
+}
+
+func TestLabelAwareEdgesSingleProducerMismatch(t *testing.T) {
+	g := newGraph()
+	g.nodes["foo"] = nodeInfo{kind: recorded, labels: map[string]string{"job": "api"}}
+	g.producers["foo"] = []string{"foo"}
+
+	expr, err := parser.ParseExpr(`foo{job="db"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g.nexts = nil
+	parser.Walk(g, expr, nil)
+
+	// foo's only producer stamps job="api", incompatible with this
+	// selector's job="db". The fallback must not resolve to "foo"
+	// itself - that's the real, incompatible producer's node ID.
+	if want := "foo{unmatched}"; strings.Join(g.nexts, ",") != want {
+		t.Errorf("saw %v, want %v", g.nexts, want)
+	}
+}
+
+func TestLabelsCompatible(t *testing.T) {
+	cases := []struct {
+		name     string
+		stamped  map[string]string
+		selector string
+		want     bool
+	}{
+		{"equal match", map[string]string{"job": "api"}, `m{job="api"}`, true},
+		{"equal mismatch", map[string]string{"job": "api"}, `m{job="db"}`, false},
+		{"absent label treated as empty", map[string]string{"job": "api"}, `m{env="prod"}`, false},
+		{"bare not-empty on unknown label is lenient", map[string]string{"job": "api"}, `m{env!=""}`, true},
+	}
+
+	for _, c := range cases {
+		expr, err := parser.ParseExpr(c.selector)
+		if err != nil {
+			t.Errorf("case %q: unexpected error: %v", c.name, err)
+			continue
+		}
+		vs := expr.(*parser.VectorSelector)
+
+		got := labelsCompatible(c.stamped, vs.LabelMatchers)
+		if got != c.want {
+			t.Errorf("case %q: saw %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mermaidEmitter renders a Graph as a Mermaid flowchart, registered
+// under the name "mermaid". Unlike DOT, this renders inline in
+// GitHub/GitLab markdown without a Graphviz toolchain.
+type mermaidEmitter struct{}
+
+// mermaidID maps a rule/metric name to a Mermaid-safe node
+// identifier. Mermaid IDs can't contain the ":" that recording rule
+// names conventionally use, the "#" registerNode suffixes
+// multi-producer names with, or the "{"/"}" of a synthetic
+// "{unmatched}" unknown node.
+func mermaidID(name string) string {
+	return strings.NewReplacer(
+		":", "_",
+		" ", "_",
+		"#", "_",
+		"{", "_",
+		"}", "_",
+	).Replace(name)
+}
+
+func (mermaidEmitter) Emit(g *Graph, w io.Writer) error {
+	fmt.Fprintf(w, "flowchart LR\n")
+
+	for name, info := range g.nodes {
+		id := mermaidID(name)
+		switch info.kind {
+		case recorded:
+			fmt.Fprintf(w, "  %s([%s])\n", id, name)
+		case alert:
+			fmt.Fprintf(w, "  %s{{%s}}\n", id, name)
+		default:
+			fmt.Fprintf(w, "  %s[%s]\n", id, name)
+		}
+	}
+
+	for from, tos := range g.adj {
+		for _, to := range tos {
+			fmt.Fprintf(w, "  %s --> %s\n", mermaidID(from), mermaidID(to))
+		}
+	}
+
+	return nil
+}
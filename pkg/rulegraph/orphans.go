@@ -0,0 +1,88 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import "sort"
+
+// alertReachable returns the set of nodes reachable by following
+// edges from every alert node - i.e. everything at least one alert
+// (transitively) depends on.
+func (g *Graph) alertReachable() map[string]bool {
+	seen := make(map[string]bool)
+
+	var visit func(string)
+	visit = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, next := range g.adj[name] {
+			visit(next)
+		}
+	}
+
+	for name, info := range g.nodes {
+		if info.kind == alert {
+			visit(name)
+		}
+	}
+
+	return seen
+}
+
+// Orphans finds two common rule-repo maintenance mistakes:
+//
+// recordedUnused is every recording rule that no alert (directly or
+// transitively) depends on - the two recording rules left behind
+// after the alert that read them was deleted.
+//
+// alertsWithoutRoute is every alert whose expression only references
+// metrics this graph has no rule for, which usually means a typo or a
+// metric that stopped being produced a while ago.
+func (g *Graph) Orphans() (recordedUnused []string, alertsWithoutRoute []string) {
+	reachable := g.alertReachable()
+
+	for name, info := range g.nodes {
+		if info.kind == recorded && !reachable[name] {
+			recordedUnused = append(recordedUnused, name)
+		}
+	}
+
+	for name, info := range g.nodes {
+		if info.kind != alert {
+			continue
+		}
+
+		next := g.adj[name]
+		if len(next) == 0 {
+			continue
+		}
+
+		onlyUnknown := true
+		for _, n := range next {
+			if g.nodes[n].kind != unknown {
+				onlyUnknown = false
+				break
+			}
+		}
+		if onlyUnknown {
+			alertsWithoutRoute = append(alertsWithoutRoute, name)
+		}
+	}
+
+	sort.Strings(recordedUnused)
+	sort.Strings(alertsWithoutRoute)
+
+	return recordedUnused, alertsWithoutRoute
+}
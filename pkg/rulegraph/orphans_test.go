@@ -0,0 +1,40 @@
+// Copyright 2021 Ingvar Mattsson
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulegraph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	g := newGraph()
+	g.nodes["used:rule"] = nodeInfo{kind: recorded}
+	g.nodes["unused:rule"] = nodeInfo{kind: recorded}
+	g.nodes["GoodAlert"] = nodeInfo{kind: alert}
+	g.nodes["StaleAlert"] = nodeInfo{kind: alert}
+	g.nodes["some_stale_metric"] = nodeInfo{kind: unknown}
+	g.adj["GoodAlert"] = []string{"used:rule"}
+	g.adj["StaleAlert"] = []string{"some_stale_metric"}
+
+	recordedUnused, alertsWithoutRoute := g.Orphans()
+
+	if want := "unused:rule"; strings.Join(recordedUnused, ",") != want {
+		t.Errorf("recordedUnused: saw %v, want %v", recordedUnused, want)
+	}
+
+	if want := "StaleAlert"; strings.Join(alertsWithoutRoute, ",") != want {
+		t.Errorf("alertsWithoutRoute: saw %v, want %v", alertsWithoutRoute, want)
+	}
+}
@@ -15,9 +15,10 @@ package rulegraph
 
 import (
 	"fmt"
-	"io"
 	"strings"
 
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
 	"github.com/prometheus/prometheus/promql/parser"
 )
@@ -60,22 +61,99 @@ func (ce *compoundError) acc(e ...error) {
 	ce.errors = append(ce.errors, e...)
 }
 
+// nodeInfo carries everything we know about a single node (rule or
+// unresolved metric) in the graph. Most of this is only populated for
+// nodes that came from a rule we actually parsed; nodes created for
+// metrics referenced by, but not produced by, any rule (ruleType
+// unknown) only ever have kind set.
+type nodeInfo struct {
+	kind ruleType
+
+	// group is the name of the rule group this node's rule was
+	// defined in. Empty for unknown nodes.
+	group         string
+	groupInterval model.Duration
+	queryOffset   model.Duration
+	limit         int
+
+	for_          model.Duration
+	keepFiringFor model.Duration
+	labels        map[string]string
+	annotations   map[string]string
+}
+
+// edgeInfo carries the computed "is this edge safe to read across"
+// information, in addition to acting as the set-membership marker the
+// plain map[string]bool used to be.
+type edgeInfo struct {
+	// stale is true when the consumer (edge source) can observe a
+	// read of data that is older than the producer's (edge
+	// target's) most recent write, given their respective
+	// group intervals and query offsets.
+	stale bool
+	delay model.Duration
+}
+
 // Nodes and edges for a graph. This also has some internal data
 // structures to facilitate walking the expression tree(s) of rules.
 type Graph struct {
-	nodes map[string]ruleType
-	edges map[string]bool
+	nodes map[string]nodeInfo
+	edges map[string]edgeInfo
 	nexts []string
+
+	// adj is the successor adjacency list (consumer -> producers it
+	// reads from), kept alongside edges so traversals (e.g. cycle
+	// detection) don't have to re-parse the DOT-ready edge strings.
+	adj map[string][]string
+
+	// producers maps a bare metric name to every node ID that
+	// produces it. Usually this is a single ID equal to the metric
+	// name itself, but multi-tenant rule sets that reuse a metric
+	// name across groups with different stamped labels get one ID
+	// per producer so Visit can tell them apart.
+	producers map[string][]string
 }
 
 // Create a new, emplty, graph that is ready to use.
 func newGraph() *Graph {
 	rv := new(Graph)
-	rv.nodes = make(map[string]ruleType)
-	rv.edges = make(map[string]bool)
+	rv.nodes = make(map[string]nodeInfo)
+	rv.edges = make(map[string]edgeInfo)
+	rv.adj = make(map[string][]string)
+	rv.producers = make(map[string][]string)
 	return rv
 }
 
+// labelsCompatible reports whether a node's stamped output labels
+// could possibly satisfy every one of a VectorSelector's label
+// matchers. A label this node doesn't stamp is assumed to be ""
+// (promql's own convention for absent labels), except that we give
+// the benefit of the doubt to a bare "!=\"\"" matcher on a label we
+// have no information about, since we can't prove the underlying
+// series doesn't set it.
+func labelsCompatible(stamped map[string]string, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Name == labels.MetricName {
+			// Already accounted for by the producer lookup that
+			// got us this candidate in the first place.
+			continue
+		}
+
+		val, present := stamped[m.Name]
+		if !present {
+			if m.Type == labels.MatchNotEqual && m.Value == "" {
+				continue
+			}
+			val = ""
+		}
+		if !m.Matches(val) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Method implementing the promql parser Visitor interface.
 func (g *Graph) Visit(node parser.Node, path []parser.Node) (parser.Visitor, error) {
 	if node == nil && path == nil {
@@ -89,8 +167,8 @@ func (g *Graph) Visit(node parser.Node, path []parser.Node) (parser.Visitor, err
 			// We need to parse out possible alerts...
 			for _, m := range vs.LabelMatchers {
 				if m.Name == "alertname" {
-					for name, rt := range g.nodes {
-						if rt == alert && m.Matches(name) {
+					for name, info := range g.nodes {
+						if info.kind == alert && m.Matches(name) {
 							g.nexts = append(g.nexts, name)
 						}
 					}
@@ -98,7 +176,31 @@ func (g *Graph) Visit(node parser.Node, path []parser.Node) (parser.Visitor, err
 				}
 			}
 		case vs.Name != "":
-			g.nexts = append(g.nexts, vs.Name)
+			candidates := g.producers[vs.Name]
+			matched := false
+			for _, id := range candidates {
+				if labelsCompatible(g.nodes[id].labels, vs.LabelMatchers) {
+					g.nexts = append(g.nexts, id)
+					matched = true
+				}
+			}
+			if !matched {
+				if len(candidates) == 0 {
+					// Nothing produces this metric at all; fall
+					// back to a bare unknown node named after it.
+					g.nexts = append(g.nexts, vs.Name)
+				} else {
+					// Something produces this metric, but none of
+					// the producers' stamped labels are compatible
+					// with this selector. vs.Name itself is already
+					// a real producer's node ID (registerNode only
+					// suffixes the second and later producers), so
+					// reusing it here would wrongly draw an edge to
+					// that incompatible producer. Synthesize a
+					// distinct unknown node instead.
+					g.nexts = append(g.nexts, vs.Name+"{unmatched}")
+				}
+			}
 		}
 	}
 
@@ -107,34 +209,50 @@ func (g *Graph) Visit(node parser.Node, path []parser.Node) (parser.Visitor, err
 
 // Contruct a string representing the edge from one node to another.
 func buildEdge(from, to string) string {
-	if strings.Contains(from, ":") {
-		from = fmt.Sprintf("\"%s\"", from)
-	}
-
-	if strings.Contains(to, ":") {
-		to = fmt.Sprintf("\"%s\"", to)
-	}
+	return fmt.Sprintf("%s -> %s", dotName(from), dotName(to))
+}
 
-	return fmt.Sprintf("%s -> %s", from, to)
+// effectiveDelay computes how stale the data a consumer rule observes
+// from a producer rule can be: the difference in their query offsets,
+// plus however long the producer takes to re-evaluate. A negative
+// value means the consumer can run before the producer has written
+// the sample it expects to read.
+func effectiveDelay(consumer, producer nodeInfo) model.Duration {
+	return consumer.queryOffset - producer.queryOffset + producer.groupInterval
 }
 
 // Get all edges from one node to successors. This basically means
 // "parse the expression, then traverse the expression AST, looking
-// for metrics".
-func (g *Graph) getEdges(r rulefmt.RuleNode) {
+// for metrics". group is the rule group r was taken from; it is not
+// read here, as the evaluation delays are worked out from the group
+// interval and query offset already stored in each node's nodeInfo.
+// id is this rule's own node ID, which may differ from its bare
+// metric name - see producers.
+func (g *Graph) getEdges(group rulefmt.RuleGroup, r rulefmt.RuleNode, id string) {
 	g.nexts = []string{}
 
 	expr, _ := parser.ParseExpr(r.Expr.Value)
 
 	_ = parser.Walk(g, expr, nil)
 
-	from := ruleName(r)
+	from := id
+	consumer := g.nodes[from]
 	for _, next := range g.nexts {
 		edge := buildEdge(from, next)
-		g.edges[edge] = true
-		if _, ok := g.nodes[next]; !ok {
-			g.nodes[next] = unknown
+		producer, ok := g.nodes[next]
+		if !ok {
+			producer = nodeInfo{kind: unknown}
+			g.nodes[next] = producer
+		}
+
+		info := edgeInfo{}
+		if producer.kind == recorded {
+			delay := effectiveDelay(consumer, producer)
+			info.delay = delay
+			info.stale = delay < 0
 		}
+		g.edges[edge] = info
+		g.adj[from] = append(g.adj[from], next)
 	}
 }
 
@@ -161,6 +279,91 @@ func ruleName(r rulefmt.RuleNode) string {
 	return name
 }
 
+// Parse any label matchers embedded directly in a record/alert name,
+// e.g. "foo{job=\"api\"}", into a plain map. Returns nil (and is a
+// no-op) for the common case of a bare name.
+func inlineLabels(name string) map[string]string {
+	if !strings.Contains(name, "{") {
+		return nil
+	}
+
+	matchers, err := parser.ParseMetricSelector(name)
+	if err != nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(matchers))
+	for _, m := range matchers {
+		if m.Type == labels.MatchEqual {
+			out[m.Name] = m.Value
+		}
+	}
+
+	return out
+}
+
+// stampedLabels returns the labels a rule stamps onto the series it
+// produces: whatever's embedded in its record/alert name, overridden
+// by its explicit labels: stanza.
+func stampedLabels(r rulefmt.RuleNode) map[string]string {
+	name := r.Record.Value
+	if name == "" {
+		name = r.Alert.Value
+	}
+
+	out := inlineLabels(name)
+	if len(r.Labels) == 0 {
+		return out
+	}
+	if out == nil {
+		out = make(map[string]string, len(r.Labels))
+	}
+	for k, v := range r.Labels {
+		out[k] = v
+	}
+
+	return out
+}
+
+// Build the nodeInfo for a single rule within group.
+func buildNodeInfo(group rulefmt.RuleGroup, r rulefmt.RuleNode) nodeInfo {
+	info := nodeInfo{
+		kind:          getType(r),
+		group:         group.Name,
+		groupInterval: model.Duration(group.Interval),
+		limit:         group.Limit,
+		for_:          model.Duration(r.For),
+		keepFiringFor: model.Duration(r.KeepFiringFor),
+		labels:        stampedLabels(r),
+		annotations:   r.Annotations,
+	}
+
+	if group.QueryOffset != nil {
+		info.queryOffset = model.Duration(*group.QueryOffset)
+	}
+
+	return info
+}
+
+// registerNode adds rule to the graph, under a fresh node ID if its
+// bare metric name is already taken by another producer (a
+// multi-tenant rule set reusing a metric name across groups with
+// different stamped labels), and returns the ID it was stored under.
+func (g *Graph) registerNode(group rulefmt.RuleGroup, rule rulefmt.RuleNode) string {
+	base := ruleName(rule)
+	info := buildNodeInfo(group, rule)
+
+	id := base
+	if existing := g.producers[base]; len(existing) > 0 {
+		id = fmt.Sprintf("%s#%d", base, len(existing)+1)
+	}
+
+	g.nodes[id] = info
+	g.producers[base] = append(g.producers[base], id)
+
+	return id
+}
+
 // Build a diagram of the interdependency of all rule files passed
 // in. We expect that these have already been checked for errors and
 // passed that check.
@@ -169,49 +372,25 @@ func ruleName(r rulefmt.RuleNode) string {
 // graph to w.
 func BuildRuleDiagram(groups []rulefmt.RuleGroup) *Graph {
 	g := newGraph()
+	ids := make(map[*rulefmt.RuleNode]string)
 
 	for _, group := range groups {
-		for _, rule := range group.Rules {
-			g.nodes[ruleName(rule)] = getType(rule)
+		for ix := range group.Rules {
+			rule := &group.Rules[ix]
+			ids[rule] = g.registerNode(group, *rule)
 		}
 	}
 
 	for _, group := range groups {
-		for _, rule := range group.Rules {
-			g.getEdges(rule)
+		for ix := range group.Rules {
+			rule := &group.Rules[ix]
+			g.getEdges(group, *rule, ids[rule])
 		}
 	}
 
 	return g
 }
 
-func EmitGraph(g *Graph, w io.Writer) {
-	fmt.Fprintf(w, "digraph {\n")
-	for name, t := range g.nodes {
-		if strings.Contains(name, ":") {
-			name = fmt.Sprintf("\"%s\"", name)
-		}
-		switch {
-		case t == recorded:
-			fmt.Fprintf(w, "  %s [shape=oval]\n", name)
-		case t == alert:
-			fmt.Fprintf(w, "  %s [shape=doubleoctagon]\n", name)
-		case t == unknown:
-			fmt.Fprintf(w, "  %s [shape=rect]\n", name)
-		default:
-			fmt.Fprintf(w, "  /* Unknown node type %v for %s */\n", t, name)
-		}
-	}
-
-	fmt.Fprintf(w, "\n")
-
-	for edge := range g.edges {
-		fmt.Fprintf(w, "  %s\n", edge)
-	}
-
-	fmt.Fprintf(w, "}\n")
-}
-
 func LoadRulefile(filename string) ([]rulefmt.RuleGroup, error) {
 	var ce compoundError
 
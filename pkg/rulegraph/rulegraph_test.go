@@ -72,8 +72,8 @@ func graphEq(g1, g2 *Graph, t *testing.T) {
 	}
 
 	for key, _ := range nodes {
-		if g1.nodes[key] != g2.nodes[key] {
-			t.Errorf("Node %s, saw type %d, expected %d", key, g1.nodes[key], g2.nodes[key])
+		if g1.nodes[key].kind != g2.nodes[key].kind {
+			t.Errorf("Node %s, saw type %d, expected %d", key, g1.nodes[key].kind, g2.nodes[key].kind)
 		}
 	}
 }
@@ -144,19 +144,19 @@ func buildGraph(rules, alerts, unknowns []string, edges []string) *Graph {
 	g := newGraph()
 
 	for _, name := range rules {
-		g.nodes[name] = recorded
+		g.nodes[name] = nodeInfo{kind: recorded}
 	}
 
 	for _, name := range alerts {
-		g.nodes[name] = alert
+		g.nodes[name] = nodeInfo{kind: alert}
 	}
 
 	for _, name := range unknowns {
-		g.nodes[name] = unknown
+		g.nodes[name] = nodeInfo{kind: unknown}
 	}
 
 	for _, edge := range edges {
-		g.edges[edge] = true
+		g.edges[edge] = edgeInfo{}
 	}
 
 	return g